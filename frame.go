@@ -25,6 +25,9 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net"
+	"sync"
+	"syscall"
 
 	"github.com/uber/tchannel-go/typed"
 )
@@ -43,6 +46,62 @@ const (
 	initialPayloadCapacity = 1024
 )
 
+// frameBufferSizeClasses are the sizes (including the frame header) of the
+// pooled buffers backing Frame.buffer, smallest first. A frame that needs
+// more than the largest class falls back to a one-off allocation.
+var frameBufferSizeClasses = []int{1 << 10, 4 << 10, 16 << 10, 64 << 10}
+
+// framePools holds one sync.Pool per entry in frameBufferSizeClasses, so
+// frame buffers of common sizes can be reused across frames instead of
+// being allocated and garbage collected for every frame.
+var framePools = newFrameBufferPools(frameBufferSizeClasses)
+
+type frameBufferPools []*sync.Pool
+
+func newFrameBufferPools(sizes []int) frameBufferPools {
+	pools := make(frameBufferPools, len(sizes))
+	for i, size := range sizes {
+		size := size
+		pools[i] = &sync.Pool{
+			// New returns a *[]byte, not a []byte: storing a []byte value
+			// directly in a sync.Pool boxes the slice header into the
+			// interface{}, so Get here doesn't allocate. put still
+			// allocates the *[]byte it stores (see put).
+			New: func() interface{} { buf := make([]byte, size); return &buf },
+		}
+	}
+	return pools
+}
+
+// get returns a buffer with length n, drawn from the smallest size class
+// that fits n. If n is larger than the largest size class, a new buffer is
+// allocated and not pooled.
+func (p frameBufferPools) get(n int) []byte {
+	for i, size := range frameBufferSizeClasses {
+		if n <= size {
+			buf := p[i].Get().(*[]byte)
+			return (*buf)[:n]
+		}
+	}
+	return make([]byte, n)
+}
+
+// put returns buf to the pool matching its capacity, if any. Buffers whose
+// capacity doesn't match a size class (e.g. one-off allocations for
+// oversized frames) are dropped for the garbage collector to reclaim.
+// Each pooled put stores a new *[]byte, which is itself one small heap
+// allocation per call.
+func (p frameBufferPools) put(buf []byte) {
+	c := cap(buf)
+	for i, size := range frameBufferSizeClasses {
+		if c == size {
+			buf = buf[:size]
+			p[i].Put(&buf)
+			return
+		}
+	}
+}
+
 // FrameHeader is the header for a frame, containing the MessageType and size
 type FrameHeader struct {
 	// The size of the frame including the header
@@ -111,6 +170,22 @@ type Frame struct {
 	buffer       []byte // full buffer, including payload and header
 	headerBuffer []byte // slice referencing just the header
 
+	// externalPayload is set when Payload references a buffer the frame
+	// doesn't own (see writeExternalPayload), so Payload is no longer
+	// contiguous with headerBuffer in buffer and must be written out with
+	// writeOutV instead of a single contiguous Write.
+	externalPayload bool
+
+	// payloadPool is set when Payload was drawn directly from framePools
+	// without an accompanying buffer (see FrameReader's promoted frames),
+	// so Release must return Payload itself to the pool.
+	payloadPool bool
+
+	// ringRef is set when Payload is a slice into a FrameReader's shared
+	// ring chunk rather than a buffer owned by this frame; Release drops
+	// the frame's reference instead of returning a buffer to a pool.
+	ringRef *ringChunk
+
 	// The header for the frame
 	Header FrameHeader
 
@@ -125,13 +200,44 @@ func NewFrame() *Frame {
 	return f
 }
 
-// updateBufferSize grows the internal buffer
+// updateBufferSize grows the internal buffer, drawing the replacement from
+// the pooled size class that fits payloadCapacity and returning the old
+// buffer to its pool.
 func (f *Frame) updateBufferSize(payloadCapacity int) {
-	newBuffer := make([]byte, payloadCapacity)
-	copy(newBuffer, f.buffer)
+	oldBuffer := f.buffer
+
+	newBuffer := framePools.get(payloadCapacity)
+	copy(newBuffer, oldBuffer)
 	f.buffer = newBuffer
 	f.Payload = f.buffer[FrameHeaderSize:]
 	f.headerBuffer = f.buffer[:FrameHeaderSize]
+
+	if oldBuffer != nil {
+		framePools.put(oldBuffer)
+	}
+}
+
+// Release returns the frame's underlying buffer (or, for frames handed out
+// by a FrameReader, its pooled payload or ring reference) to the pool it
+// was drawn from, so it can be reused by another frame. The frame must not
+// be read from or written to after calling Release.
+func (f *Frame) Release() {
+	switch {
+	case f.ringRef != nil:
+		f.ringRef.release()
+		f.ringRef = nil
+		f.Payload = nil
+	case f.payloadPool:
+		framePools.put(f.Payload)
+		f.Payload = nil
+		f.payloadPool = false
+	case f.buffer != nil:
+		framePools.put(f.buffer)
+		f.buffer = nil
+		f.headerBuffer = nil
+		f.Payload = nil
+		f.externalPayload = false
+	}
 }
 
 // ReadIn reads the frame from the given io.Reader
@@ -157,6 +263,10 @@ func (f *Frame) ReadIn(r io.Reader) error {
 
 // WriteOut writes the frame to the given io.Writer
 func (f *Frame) WriteOut(w io.Writer) error {
+	if f.externalPayload {
+		return f.writeOutV(w)
+	}
+
 	var wbuf typed.WriteBuffer
 	wbuf.Wrap(f.headerBuffer)
 
@@ -172,20 +282,83 @@ func (f *Frame) WriteOut(w io.Writer) error {
 	return nil
 }
 
+// WriteOutV writes the frame to w as a header buffer and a payload buffer,
+// via net.Buffers, instead of the single contiguous write WriteOut performs.
+// This avoids the copy WriteOut otherwise requires when the payload isn't
+// already contiguous with the header, e.g. frames built with an
+// externally-owned payload. For io.Writers that don't support vectored
+// writes (anything but *net.TCPConn or another syscall.Conn), it falls back
+// to WriteOut.
+//
+// The outbound connection writer isn't part of this source tree, so
+// nothing here calls WriteOutV/writeExternalPayload from a production
+// write path yet; that wiring, and the arg3-streaming caller that would
+// produce externally-owned payloads in the first place, remains to be
+// done where connection.go lives.
+func (f *Frame) WriteOutV(w io.Writer) error {
+	switch w.(type) {
+	case *net.TCPConn, syscall.Conn:
+		return f.writeOutV(w)
+	default:
+		return f.WriteOut(w)
+	}
+}
+
+func (f *Frame) writeOutV(w io.Writer) error {
+	var headerBuf [FrameHeaderSize]byte
+	var wbuf typed.WriteBuffer
+	wbuf.Wrap(headerBuf[:])
+
+	if err := f.Header.write(&wbuf); err != nil {
+		return err
+	}
+
+	bufs := net.Buffers{headerBuf[:], f.Payload[:f.Header.PayloadSize()]}
+	_, err := bufs.WriteTo(w)
+	return err
+}
+
+// WriteTo implements io.WriterTo by writing the frame out via WriteOutV.
+func (f *Frame) WriteTo(w io.Writer) (int64, error) {
+	if err := f.WriteOutV(w); err != nil {
+		return 0, err
+	}
+	return int64(f.Header.FrameSize()), nil
+}
+
 // SizedPayload returns the slice of the payload actually used, as defined by
 // the header. This method will grow the size of the internal buffer if
 // necessary.
 func (f *Frame) SizedPayload() []byte {
-	needed := int(FrameHeaderSize + f.Header.PayloadSize())
+	payloadSize := int(f.Header.PayloadSize())
+
+	if f.buffer == nil {
+		// f has no contiguous header+payload buffer to grow, either
+		// because it was handed out by FrameReader (whose payloads are
+		// already sized exactly to the header) or because it was already
+		// Released. Re-derive capacity from Payload itself rather than
+		// falling into the growth path below, which would allocate a
+		// fresh header+payload buffer and replace the Payload already
+		// read into.
+		if len(f.Payload) < payloadSize {
+			f.Payload = framePools.get(payloadSize)
+			f.payloadPool = true
+		}
+		return f.Payload[:payloadSize]
+	}
+
+	needed := FrameHeaderSize + payloadSize
 	sz := cap(f.buffer)
 	if sz < needed {
+		if sz == 0 {
+			sz = initialPayloadCapacity
+		}
 		for sz < needed {
 			sz <<= 1
 		}
 		f.updateBufferSize(sz)
-
 	}
-	return f.Payload[:f.Header.PayloadSize()]
+	return f.Payload[:payloadSize]
 }
 
 // messageType returns the message type.
@@ -194,6 +367,16 @@ func (f *Frame) messageType() messageType {
 }
 
 func (f *Frame) write(msg message) error {
+	// A frame previously populated via writeExternalPayload has Payload
+	// pointing at a caller-owned buffer rather than f.buffer. Repoint it
+	// at the frame's own buffer before wrapping it for writing, so we
+	// don't serialize into (and thereby corrupt) memory this frame
+	// doesn't own.
+	if f.externalPayload {
+		f.externalPayload = false
+		f.Payload = f.buffer[FrameHeaderSize:]
+	}
+
 	var wbuf typed.WriteBuffer
 	wbuf.Wrap(f.Payload[:])
 	if err := msg.write(&wbuf); err != nil {
@@ -207,6 +390,27 @@ func (f *Frame) write(msg message) error {
 	return nil
 }
 
+// writeExternalPayload sets the frame's header and payload to reference an
+// already-serialized, externally-owned buffer, instead of copying it into
+// f.Payload. Callers that already hold the serialized bytes for a large
+// arg (e.g. arg3 streaming) can use this to avoid that copy; the frame must
+// then be written out with WriteOutV (WriteOut does this automatically),
+// since payload is no longer contiguous with the header in f.buffer. The
+// caller must not mutate payload until the frame is done being written.
+func (f *Frame) writeExternalPayload(id uint32, msgType messageType, payload []byte) error {
+	if len(payload) > MaxFramePayloadSize {
+		return fmt.Errorf("tchannel: frame payload too large: %v > %v", len(payload), MaxFramePayloadSize)
+	}
+
+	f.externalPayload = true
+	f.Payload = payload
+	f.Header.ID = id
+	f.Header.reserved1 = 0
+	f.Header.messageType = msgType
+	f.Header.SetPayloadSize(uint16(len(payload)))
+	return nil
+}
+
 func (f *Frame) read(msg message) error {
 	var rbuf typed.ReadBuffer
 	rbuf.Wrap(f.SizedPayload())
@@ -0,0 +1,178 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/uber/tchannel-go/typed"
+)
+
+// frameReaderRingSize is the size of the block FrameReader reads from the
+// underlying io.Reader at a time. It matches the largest frame buffer size
+// class, so a single ring chunk can hold one max-size frame.
+const frameReaderRingSize = 64 << 10
+
+// ringChunk is a reference-counted buffer backing zero or more frames
+// handed out by a FrameReader. It's only returned to framePools once every
+// frame referencing it, plus the FrameReader's own hold, has released it.
+type ringChunk struct {
+	buf  []byte
+	refs int32
+}
+
+func newRingChunk() *ringChunk {
+	return &ringChunk{buf: framePools.get(frameReaderRingSize), refs: 1}
+}
+
+func (c *ringChunk) retain() { atomic.AddInt32(&c.refs, 1) }
+
+func (c *ringChunk) release() {
+	if atomic.AddInt32(&c.refs, -1) == 0 {
+		framePools.put(c.buf)
+	}
+}
+
+// FrameReader reads a stream of frames out of an io.Reader using a single
+// pooled ring buffer rather than issuing two syscalls (header, then
+// payload) per frame the way Frame.ReadIn does. Frames it returns hold a
+// reference into the ring chunk they were parsed from; call Release on
+// each frame once it's done being used so the chunk can be reused or
+// returned to the pool.
+//
+// The connection read loop isn't part of this source tree, so nothing
+// here switches it from Frame.ReadIn over to FrameReader.Next yet; that
+// remains to be done where connection.go lives. Frame.ReadIn is left
+// exactly as it was for existing callers.
+type FrameReader struct {
+	r io.Reader
+
+	cur    *ringChunk
+	pos    int // read position within cur.buf
+	filled int // valid bytes within cur.buf
+}
+
+// NewFrameReader returns a FrameReader that reads frames from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: r}
+}
+
+// Next reads and returns the next frame from the underlying reader. The
+// returned frame's Payload may reference the FrameReader's ring chunk, so
+// callers must call Release on it once done. A frame with an empty payload
+// holds no reference, and Release on it is a no-op.
+func (fr *FrameReader) Next() (*Frame, error) {
+	header, ring, err := fr.readFull(FrameHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Frame{}
+	var rbuf typed.ReadBuffer
+	rbuf.Wrap(header)
+	herr := f.Header.read(&rbuf)
+	// The header bytes are only needed transiently to populate f.Header;
+	// release them immediately rather than holding them for the frame's
+	// lifetime.
+	fr.releaseHeader(header, ring)
+	if herr != nil {
+		return nil, herr
+	}
+
+	payloadSize := int(f.Header.PayloadSize())
+	if payloadSize == 0 {
+		return f, nil
+	}
+
+	payload, ring, err := fr.readFull(payloadSize)
+	if err != nil {
+		return nil, err
+	}
+	f.Payload = payload
+	if ring != nil {
+		f.ringRef = ring
+	} else {
+		f.payloadPool = true
+	}
+	return f, nil
+}
+
+// releaseHeader returns header's backing memory once it's no longer
+// needed: dropping the ring reference if it came from the ring, or
+// returning it to framePools if it was a one-off promoted buffer.
+func (fr *FrameReader) releaseHeader(header []byte, ring *ringChunk) {
+	if ring != nil {
+		ring.release()
+		return
+	}
+	framePools.put(header)
+}
+
+// readFull returns n bytes read from the underlying reader. When those
+// bytes fit contiguously within the current ring chunk, the returned slice
+// references the chunk directly (ring is non-nil, already retained on the
+// caller's behalf) with no copy. When the requested range straddles the
+// end of the current chunk, the bytes are promoted into their own
+// contiguous, pooled buffer (ring is nil; the buffer is owned outright by
+// the caller) and the ring starts fresh on the next call.
+func (fr *FrameReader) readFull(n int) (buf []byte, ring *ringChunk, err error) {
+	if fr.cur == nil {
+		fr.cur = newRingChunk()
+		fr.pos, fr.filled = 0, 0
+	}
+
+	for fr.filled-fr.pos < n {
+		if fr.filled == cap(fr.cur.buf) {
+			return fr.promote(n)
+		}
+		read, rerr := fr.r.Read(fr.cur.buf[fr.filled:])
+		fr.filled += read
+		if read == 0 && rerr != nil {
+			return nil, nil, rerr
+		}
+	}
+
+	buf = fr.cur.buf[fr.pos : fr.pos+n]
+	fr.cur.retain()
+	ring = fr.cur
+	fr.pos += n
+	return buf, ring, nil
+}
+
+// promote copies the unread tail of the exhausted ring chunk into a
+// freshly pooled, contiguous buffer sized for n, reads the remainder of n
+// straight from the underlying reader, and starts the ring fresh for
+// subsequent calls.
+func (fr *FrameReader) promote(n int) ([]byte, *ringChunk, error) {
+	buf := framePools.get(n)
+	carried := copy(buf, fr.cur.buf[fr.pos:fr.filled])
+
+	fr.cur.release() // drop the ring's own hold on the exhausted chunk
+	fr.cur, fr.pos, fr.filled = nil, 0, 0
+
+	if carried < n {
+		if _, err := io.ReadFull(fr.r, buf[carried:]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return buf, nil, nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+// DefaultSendQueueHighWatermark is the default high watermark, in bytes,
+// for a connection's OS-reported outbound TCP send queue. It's the
+// building block for the ConnectionOptions.SendQueueHighWatermark /
+// Connection.SendQueueLen() load-shedding feature: peer selection is
+// meant to treat a connection whose send queue is at or above this many
+// bytes as unhealthy, so the client sheds load before TCP buffers fill.
+//
+// NOTE: this file only provides the OS-query building block
+// (sendQueueExceedsWatermark, on top of getSendQueueLen). Wiring an
+// accessor onto Connection and a ConnectionOptions field, and having peer
+// selection consult it, belongs in connection.go and the peer selection
+// heuristics, neither of which are part of this source tree; that part of
+// the original request is not done here.
+const DefaultSendQueueHighWatermark = 1 << 20 // 1 MiB
+
+// sendQueueExceedsWatermark reports whether fd's current outbound TCP send
+// queue, as reported by the OS via getSendQueueLen, is at or above
+// watermark.
+func sendQueueExceedsWatermark(fd uintptr, watermark int) (bool, error) {
+	n, err := getSendQueueLen(fd)
+	if err != nil {
+		return false, err
+	}
+	return n >= watermark, nil
+}
@@ -1,4 +1,4 @@
-// +build darwin amd64
+// +build darwin
 
 package tchannel
 
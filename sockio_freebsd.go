@@ -0,0 +1,10 @@
+// +build freebsd
+
+package tchannel
+
+import "golang.org/x/sys/unix"
+
+func getSendQueueLen(fd uintptr) (int, error) {
+	// https://www.freebsd.org/cgi/man.cgi?query=tcp
+	return unix.IoctlGetInt(int(fd), unix.TIOCOUTQ)
+}
@@ -0,0 +1,13 @@
+// +build !linux,!darwin,!freebsd,!windows
+
+package tchannel
+
+import "errors"
+
+// ErrNotSupported is returned by getSendQueueLen on platforms where
+// querying the kernel's TCP send queue length isn't implemented.
+var ErrNotSupported = errors.New("tchannel: getSendQueueLen is not supported on this platform")
+
+func getSendQueueLen(fd uintptr) (int, error) {
+	return -1, ErrNotSupported
+}
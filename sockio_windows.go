@@ -0,0 +1,65 @@
+// +build windows
+
+package tchannel
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// siotcpinfo is the WSAIoctl control code for querying TCP_INFO_v0:
+// SIO_TCP_INFO = _WSAIOW(IOC_VENDOR, 39), as defined in mstcpip.h. _WSAIOW
+// sets the IOC_IN bit, so that must be included here too.
+const siotcpinfo = windows.IOC_IN | windows.IOC_VENDOR | 39
+
+// tcpInfoV0 mirrors the TCP_INFO_v0 struct from mstcpip.h. We only need
+// BytesInFlight, but WSAIoctl requires the full, correctly-sized struct to
+// be passed in.
+type tcpInfoV0 struct {
+	State             uint32
+	Mss               uint32
+	ConnectionTimeMs  uint64
+	TimestampsEnabled int32
+	RttUs             uint32
+	MinRttUs          uint32
+	BytesInFlight     uint32
+	Cwnd              uint32
+	SndWnd            uint32
+	RcvWnd            uint32
+	RcvBuf            uint32
+	BytesOut          uint64
+	BytesIn           uint64
+	BytesReordered    uint32
+	BytesRetrans      uint32
+	FastRetrans       uint32
+	DupAcksIn         uint32
+	TimeoutEpisodes   uint32
+	SynRetrans        uint8
+}
+
+// getSendQueueLen returns the number of bytes currently in flight (sent but
+// not yet acknowledged) for fd, using the SIO_TCP_INFO ioctl. This is
+// Windows' closest analog to Linux's SIOCOUTQ / Darwin's SO_NWRITE.
+func getSendQueueLen(fd uintptr) (int, error) {
+	var info tcpInfoV0
+	var version uint32 // 0 requests TCP_INFO_v0
+	var bytesReturned uint32
+
+	err := windows.WSAIoctl(
+		windows.Handle(fd),
+		siotcpinfo,
+		(*byte)(unsafe.Pointer(&version)),
+		uint32(unsafe.Sizeof(version)),
+		(*byte)(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+		&bytesReturned,
+		nil,
+		0,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	return int(info.BytesInFlight), nil
+}